@@ -0,0 +1,127 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package analysisprovider implements the query clients used by the
+// analysis stage to decide whether a canary/blue-green rollout should
+// proceed, based on metrics or, as added here, log signals.
+package analysisprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ElasticsearchProvider runs a Lucene/DSL query over a rolling time window
+// and reports the resulting error-log rate so that the analysis stage can
+// compare it against a configured threshold.
+type ElasticsearchProvider struct {
+	client       *http.Client
+	address      string
+	indexPattern string
+	username     string
+	password     string
+	apiKey       string
+}
+
+// NewElasticsearchProvider creates a new ElasticsearchProvider.
+func NewElasticsearchProvider(client *http.Client, address, indexPattern, username, password, apiKey string) *ElasticsearchProvider {
+	return &ElasticsearchProvider{
+		client:       client,
+		address:      address,
+		indexPattern: indexPattern,
+		username:     username,
+		password:     password,
+		apiKey:       apiKey,
+	}
+}
+
+// ErrorLogRate runs the given Lucene query over [from, to) and returns the
+// ratio of documents matching errorQuery among all documents matching query.
+func (p *ElasticsearchProvider) ErrorLogRate(ctx context.Context, query, errorQuery string, from, to time.Time) (float64, error) {
+	total, err := p.count(ctx, query, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("unable to count total log entries: %w", err)
+	}
+	if total == 0 {
+		return 0, nil
+	}
+	errs, err := p.count(ctx, errorQuery, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("unable to count error log entries: %w", err)
+	}
+	return float64(errs) / float64(total), nil
+}
+
+func (p *ElasticsearchProvider) count(ctx context.Context, luceneQuery string, from, to time.Time) (int64, error) {
+	body := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": []map[string]interface{}{
+					{"query_string": map[string]interface{}{"query": luceneQuery}},
+					{"range": map[string]interface{}{
+						"@timestamp": map[string]interface{}{
+							"gte": from.Format(time.RFC3339),
+							"lt":  to.Format(time.RFC3339),
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(body); err != nil {
+		return 0, err
+	}
+
+	url := fmt.Sprintf("%s/%s/_count", p.address, p.indexPattern)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, buf)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	p.authenticate(req)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status code from elasticsearch: %d", resp.StatusCode)
+	}
+
+	var out struct {
+		Count int64 `json:"count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return 0, err
+	}
+	return out.Count, nil
+}
+
+func (p *ElasticsearchProvider) authenticate(req *http.Request) {
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+p.apiKey)
+		return
+	}
+	if p.username != "" {
+		req.SetBasicAuth(p.username, p.password)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysisprovider
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ElasticsearchQuery is the configuration of a single log-based analysis
+// query executed repeatedly by the analysis stage during a rollout.
+type ElasticsearchQuery struct {
+	// Lucene/DSL query matching all log entries considered for the window.
+	Query string
+	// Lucene/DSL query matching the error log entries among them.
+	ErrorQuery string
+	// The size of the rolling time window to evaluate on each check.
+	Interval time.Duration
+	// The stage fails once the error-log rate exceeds this value.
+	FailureThreshold float64
+}
+
+// Evaluate runs the query for the window ending at now and reports whether
+// the observed error-log rate is within FailureThreshold: expected is true
+// when the rate is at most FailureThreshold and false once it exceeds it.
+func (q ElasticsearchQuery) Evaluate(ctx context.Context, provider *ElasticsearchProvider, now time.Time) (expected bool, rate float64, err error) {
+	rate, err = provider.ErrorLogRate(ctx, q.Query, q.ErrorQuery, now.Add(-q.Interval), now)
+	if err != nil {
+		return false, 0, fmt.Errorf("unable to evaluate elasticsearch analysis query: %w", err)
+	}
+	return rate <= q.FailureThreshold, rate, nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysisprovider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestElasticsearchProviderErrorLogRate(t *testing.T) {
+	var counts = map[string]int64{
+		`kubernetes.namespace: "demo"`:                    100,
+		`kubernetes.namespace: "demo" AND level: "error"`: 5,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query struct {
+				Bool struct {
+					Must []struct {
+						QueryString struct {
+							Query string `json:"query"`
+						} `json:"query_string"`
+					} `json:"must"`
+				} `json:"bool"`
+			} `json:"query"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		query := body.Query.Bool.Must[0].QueryString.Query
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int64{"count": counts[query]})
+	}))
+	defer server.Close()
+
+	provider := NewElasticsearchProvider(server.Client(), server.URL, "logs-*", "", "", "")
+	rate, err := provider.ErrorLogRate(
+		context.Background(),
+		`kubernetes.namespace: "demo"`,
+		`kubernetes.namespace: "demo" AND level: "error"`,
+		time.Now().Add(-time.Minute),
+		time.Now(),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 0.05, rate)
+}
+
+func TestElasticsearchProviderErrorLogRateNoDocuments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int64{"count": 0})
+	}))
+	defer server.Close()
+
+	provider := NewElasticsearchProvider(server.Client(), server.URL, "logs-*", "", "", "")
+	rate, err := provider.ErrorLogRate(context.Background(), "query", "errorQuery", time.Now().Add(-time.Minute), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, float64(0), rate)
+}
+
+func TestElasticsearchQueryEvaluate(t *testing.T) {
+	testcases := []struct {
+		name      string
+		rate      float64
+		threshold float64
+		expected  bool
+	}{
+		{name: "below threshold", rate: 0.01, threshold: 0.1, expected: true},
+		{name: "at threshold", rate: 0.1, threshold: 0.1, expected: true},
+		{name: "above threshold", rate: 0.2, threshold: 0.1, expected: false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			total := int64(1000)
+			errs := int64(tc.rate * float64(total))
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body struct {
+					Query struct {
+						Bool struct {
+							Must []struct {
+								QueryString struct {
+									Query string `json:"query"`
+								} `json:"query_string"`
+							} `json:"must"`
+						} `json:"bool"`
+					} `json:"query"`
+				}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				query := body.Query.Bool.Must[0].QueryString.Query
+
+				count := total
+				if query == "errorQuery" {
+					count = errs
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(map[string]int64{"count": count})
+			}))
+			defer server.Close()
+
+			provider := NewElasticsearchProvider(server.Client(), server.URL, "logs-*", "", "", "")
+			query := ElasticsearchQuery{
+				Query:            "query",
+				ErrorQuery:       "errorQuery",
+				Interval:         time.Minute,
+				FailureThreshold: tc.threshold,
+			}
+
+			ok, _, err := query.Evaluate(context.Background(), provider, time.Now())
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ok)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+// factoryKey identifies a single shared informer factory: one per distinct
+// (namespace, selector) tuple so that multiple apps whose matchers overlap
+// share the same underlying watch instead of each paying for its own
+// full-cluster cache.
+type factoryKey struct {
+	namespace     string // "" means cluster-wide
+	labelSelector string
+	fieldSelector string
+}
+
+// AppStateInformer watches Kubernetes resources on behalf of a single Piped,
+// using one shared informer per (namespace, GVR, selector) tuple instead of
+// one full-cluster cache per application.
+type AppStateInformer struct {
+	dynamicClient dynamic.Interface
+	cfg           config.KubernetesAppStateInformer
+
+	mu        sync.Mutex
+	factories map[factoryKey]dynamicinformer.DynamicSharedInformerFactory
+}
+
+// NewAppStateInformer builds an AppStateInformer with a shared informer
+// already created for every (namespace, GVR) pair cfg resolves to watching.
+// Call Start to begin and block on those watches.
+func NewAppStateInformer(client dynamic.Interface, cfg config.KubernetesAppStateInformer) *AppStateInformer {
+	a := &AppStateInformer{
+		dynamicClient: client,
+		cfg:           cfg,
+		factories:     make(map[factoryKey]dynamicinformer.DynamicSharedInformerFactory),
+	}
+
+	gvrs := gvrsToWatch(cfg.IncludeResources, cfg.ExcludeResources)
+	for _, namespace := range namespacesToWatch(cfg) {
+		for _, gvr := range gvrs {
+			a.informerFor(namespace, gvr)
+		}
+	}
+	return a
+}
+
+func (a *AppStateInformer) resyncPeriod() time.Duration {
+	if a.cfg.ResyncPeriod > 0 {
+		return time.Duration(a.cfg.ResyncPeriod)
+	}
+	return 30 * time.Minute
+}
+
+// informerFor returns the shared informer watching gvr in namespace,
+// creating the underlying factory for that (namespace, selector) tuple on
+// first use. The label/field selectors configured for this Piped are pushed
+// down into the factory's list/watch options so unwanted resources never
+// cross the wire in the first place, rather than being filtered out
+// client-side after a full-cluster watch.
+func (a *AppStateInformer) informerFor(namespace string, gvr schema.GroupVersionResource) cache.SharedIndexInformer {
+	key := factoryKey{
+		namespace:     namespace,
+		labelSelector: a.cfg.LabelSelector,
+		fieldSelector: a.cfg.FieldSelector,
+	}
+
+	a.mu.Lock()
+	factory, ok := a.factories[key]
+	if !ok {
+		tweak := func(opts *metav1.ListOptions) {
+			opts.LabelSelector = a.cfg.LabelSelector
+			opts.FieldSelector = a.cfg.FieldSelector
+		}
+		factory = dynamicinformer.NewFilteredDynamicSharedInformerFactory(a.dynamicClient, a.resyncPeriod(), namespace, tweak)
+		a.factories[key] = factory
+	}
+	a.mu.Unlock()
+
+	informer := factory.ForResource(gvr).Informer()
+	informer.SetWatchErrorHandler(watchErrorHandler)
+	return informer
+}
+
+// Start starts every shared informer factory created so far via informerFor
+// and blocks until ctx is done.
+func (a *AppStateInformer) Start(ctx context.Context) {
+	a.mu.Lock()
+	factories := make([]dynamicinformer.DynamicSharedInformerFactory, 0, len(a.factories))
+	for _, factory := range a.factories {
+		factories = append(factories, factory)
+	}
+	a.mu.Unlock()
+
+	for _, factory := range factories {
+		factory.Start(ctx.Done())
+	}
+	for _, factory := range factories {
+		factory.WaitForCacheSync(ctx.Done())
+	}
+	<-ctx.Done()
+}
+
+// watchErrorHandler lets the informer's reflector fall back to its normal
+// bounded relist on a 410 Gone (resource version too old), but adds a small
+// random jitter beforehand so that many informers invalidated at once, e.g.
+// after an API server rollout, don't all relist in the same instant.
+func watchErrorHandler(r *cache.Reflector, err error) {
+	if cache.IsExpiredError(err) {
+		time.Sleep(time.Duration(rand.Int63n(int64(5 * time.Second))))
+	}
+	cache.DefaultWatchErrorHandler(r, err)
+}
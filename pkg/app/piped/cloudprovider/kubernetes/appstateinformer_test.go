@@ -0,0 +1,47 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+func TestNewAppStateInformerBuildsAnInformerPerNamespaceAndGVR(t *testing.T) {
+	scheme := runtime.NewScheme()
+	gvrs := map[schema.GroupVersionResource]string{
+		{Group: "apps", Version: "v1", Resource: "deployments"}: "DeploymentList",
+	}
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(scheme, gvrs)
+
+	cfg := config.KubernetesAppStateInformer{
+		Namespaces:       []string{"ns-1", "ns-2"},
+		IncludeResources: []config.KubernetesResourceMatcher{{APIVersion: "apps/v1", Kind: "Deployment"}},
+	}
+
+	informer := NewAppStateInformer(client, cfg)
+
+	assert.Len(t, informer.factories, 2)
+	for _, ns := range []string{"ns-1", "ns-2"} {
+		_, ok := informer.factories[factoryKey{namespace: ns}]
+		assert.True(t, ok, "expected a factory for namespace %q", ns)
+	}
+}
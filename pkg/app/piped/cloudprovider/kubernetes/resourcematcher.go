@@ -0,0 +1,104 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+// gvrsToWatch returns the distinct GVRs that must be watched to satisfy the
+// given include/exclude matchers, so that only those resource types are
+// informed on instead of every kind in the cluster. Matchers with an empty
+// Kind are skipped: they neither add nor exclude any resource type.
+func gvrsToWatch(include, exclude []config.KubernetesResourceMatcher) []schema.GroupVersionResource {
+	excluded := make(map[string]struct{}, len(exclude))
+	for _, m := range exclude {
+		if m.Kind == "" {
+			continue
+		}
+		excluded[m.APIVersion+"/"+m.Kind] = struct{}{}
+	}
+
+	seen := make(map[schema.GroupVersionResource]struct{})
+	gvrs := make([]schema.GroupVersionResource, 0, len(include))
+	for _, m := range include {
+		if m.Kind == "" {
+			continue
+		}
+		if _, ok := excluded[m.APIVersion+"/"+m.Kind]; ok {
+			continue
+		}
+		gvr, _ := schema.ParseGroupVersion(m.APIVersion)
+		resource := schema.GroupVersionResource{Group: gvr.Group, Version: gvr.Version, Resource: resourceNameForKind(m.Kind)}
+		if _, ok := seen[resource]; ok {
+			continue
+		}
+		seen[resource] = struct{}{}
+		gvrs = append(gvrs, resource)
+	}
+	return gvrs
+}
+
+// irregularResourceNames maps kinds whose plural resource name doesn't follow
+// the suffix rules below, e.g. because the kind is already plural.
+var irregularResourceNames = map[string]string{
+	"Endpoints": "endpoints",
+}
+
+// resourceNameForKind derives the plural resource name the Kubernetes API
+// registers a kind under (Deployment -> deployments, Ingress -> ingresses,
+// NetworkPolicy -> networkpolicies, ...). This mirrors the suffix rules
+// k8s.io/apimachinery/pkg/api/meta.UnsafeGuessKindToResource uses, since
+// resolving through discovery isn't available here: the resource matchers
+// are evaluated against static config, not a live API server connection.
+func resourceNameForKind(kind string) string {
+	lower := strings.ToLower(kind)
+	if name, ok := irregularResourceNames[kind]; ok {
+		return name
+	}
+
+	switch {
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return lower + "es"
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !isVowel(lower[len(lower)-2]):
+		return lower[:len(lower)-1] + "ies"
+	default:
+		return lower + "s"
+	}
+}
+
+func isVowel(b byte) bool {
+	switch b {
+	case 'a', 'e', 'i', 'o', 'u':
+		return true
+	default:
+		return false
+	}
+}
+
+// namespacesToWatch resolves the effective namespace list to create shared
+// informer factories for: one factory per namespace when scoped, or a
+// single cluster-wide ("") factory otherwise.
+func namespacesToWatch(cfg config.KubernetesAppStateInformer) []string {
+	if ns := cfg.WatchNamespaces(); len(ns) > 0 {
+		return ns
+	}
+	return []string{""}
+}
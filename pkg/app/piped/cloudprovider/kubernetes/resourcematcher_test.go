@@ -0,0 +1,63 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+func TestResourceNameForKind(t *testing.T) {
+	testcases := []struct {
+		kind     string
+		expected string
+	}{
+		{kind: "Deployment", expected: "deployments"},
+		{kind: "Pod", expected: "pods"},
+		{kind: "Service", expected: "services"},
+		{kind: "Ingress", expected: "ingresses"},
+		{kind: "NetworkPolicy", expected: "networkpolicies"},
+		{kind: "StorageClass", expected: "storageclasses"},
+		{kind: "Endpoints", expected: "endpoints"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.kind, func(t *testing.T) {
+			assert.Equal(t, tc.expected, resourceNameForKind(tc.kind))
+		})
+	}
+}
+
+func TestGvrsToWatch(t *testing.T) {
+	include := []config.KubernetesResourceMatcher{
+		{APIVersion: "apps/v1", Kind: "Deployment"},
+		{APIVersion: "v1", Kind: "Ingress"},
+		{APIVersion: "apps/v1", Kind: "Deployment"},
+	}
+	exclude := []config.KubernetesResourceMatcher{
+		{APIVersion: "v1", Kind: "Ingress"},
+	}
+
+	gvrs := gvrsToWatch(include, exclude)
+	assert.Len(t, gvrs, 1)
+	assert.Equal(t, "deployments", gvrs[0].Resource)
+}
+
+func TestNamespacesToWatch(t *testing.T) {
+	assert.Equal(t, []string{""}, namespacesToWatch(config.KubernetesAppStateInformer{}))
+	assert.Equal(t, []string{"ns-1"}, namespacesToWatch(config.KubernetesAppStateInformer{Namespace: "ns-1"}))
+}
@@ -0,0 +1,204 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider"
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+func init() {
+	if err := DefaultRegistry().Register(model.Stage("ANALYSIS_BY_LOG"), newLogAnalysisExecutor); err != nil {
+		panic(err)
+	}
+}
+
+// logAnalysisStageOptions is the stage config for an ANALYSIS_BY_LOG stage,
+// e.g.:
+//
+//	query: 'kubernetes.namespace: "demo"'
+//	errorQuery: 'kubernetes.namespace: "demo" AND level: "error"'
+//	interval: 1m
+//	failureThreshold: 0.1
+type logAnalysisStageOptions struct {
+	// Name of the PipedAnalysisProvider to query; must be of type ELASTICSEARCH.
+	Provider string `json:"provider"`
+	// Lucene/DSL query matching all log entries considered for the window.
+	Query string `json:"query"`
+	// Lucene/DSL query matching the error log entries among them.
+	ErrorQuery string `json:"errorQuery"`
+	// How often to re-evaluate the rolling window. Default is 1m.
+	Interval config.Duration `json:"interval"`
+	// The stage fails once the error-log rate exceeds this value.
+	FailureThreshold float64 `json:"failureThreshold"`
+}
+
+// logAnalysisExecutor gates a canary/blue-green rollout on the error-log
+// rate reported by an analysisprovider.ElasticsearchProvider, failing the
+// stage as soon as one rolling-window check exceeds FailureThreshold.
+type logAnalysisExecutor struct {
+	in Input
+}
+
+func newLogAnalysisExecutor(in Input) Executor {
+	return &logAnalysisExecutor{in: in}
+}
+
+// Execute polls the configured Elasticsearch/OpenSearch query on a fixed
+// interval until the stage's deadline, failing as soon as one window's
+// error-log rate exceeds the configured threshold.
+func (e *logAnalysisExecutor) Execute(sig StopSignal) model.StageStatus {
+	opts, err := e.loadOptions()
+	if err != nil {
+		e.in.LogPersister.Errorf("invalid ANALYSIS_BY_LOG stage config: %v", err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	provider, err := e.buildProvider(opts.Provider)
+	if err != nil {
+		e.in.LogPersister.Errorf("unable to prepare elasticsearch analysis provider %s: %v", opts.Provider, err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	interval := time.Duration(opts.Interval)
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	query := analysisprovider.ElasticsearchQuery{
+		Query:            opts.Query,
+		ErrorQuery:       opts.ErrorQuery,
+		Interval:         interval,
+		FailureThreshold: opts.FailureThreshold,
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ctx := sig.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return model.StageStatus_STAGE_CANCELLED
+		case <-ticker.C:
+			ok, rate, err := query.Evaluate(ctx, provider, time.Now())
+			if err != nil {
+				e.in.LogPersister.Errorf("unable to evaluate log analysis query: %v", err)
+				return model.StageStatus_STAGE_FAILURE
+			}
+			if !ok {
+				e.in.LogPersister.Errorf("error-log rate %.4f exceeded threshold %.4f, failing the stage", rate, opts.FailureThreshold)
+				return model.StageStatus_STAGE_FAILURE
+			}
+			e.in.LogPersister.Info(fmt.Sprintf("error-log rate %.4f is within threshold %.4f", rate, opts.FailureThreshold))
+		}
+	}
+}
+
+func (e *logAnalysisExecutor) loadOptions() (logAnalysisStageOptions, error) {
+	data, err := json.Marshal(e.in.StageConfig)
+	if err != nil {
+		return logAnalysisStageOptions{}, fmt.Errorf("unable to marshal stage config: %w", err)
+	}
+	var opts logAnalysisStageOptions
+	if err := json.Unmarshal(data, &opts); err != nil {
+		return logAnalysisStageOptions{}, fmt.Errorf("unable to unmarshal stage config: %w", err)
+	}
+	if opts.Provider == "" {
+		return logAnalysisStageOptions{}, fmt.Errorf("provider must be set")
+	}
+	if opts.Query == "" || opts.ErrorQuery == "" {
+		return logAnalysisStageOptions{}, fmt.Errorf("both query and errorQuery must be set")
+	}
+	return opts, nil
+}
+
+func (e *logAnalysisExecutor) buildProvider(name string) (*analysisprovider.ElasticsearchProvider, error) {
+	ap, ok := e.in.PipedConfig.GetAnalysisProvider(name)
+	if !ok {
+		return nil, fmt.Errorf("analysis provider %s is not configured", name)
+	}
+	if ap.ElasticsearchConfig == nil {
+		return nil, fmt.Errorf("analysis provider %s is not an elasticsearch provider", name)
+	}
+	cfg := ap.ElasticsearchConfig
+
+	var username, password, apiKey string
+	var err error
+	if cfg.UsernameFile != "" {
+		if username, err = readTrimmedFile(cfg.UsernameFile); err != nil {
+			return nil, fmt.Errorf("unable to read username file: %w", err)
+		}
+	}
+	if cfg.PasswordFile != "" {
+		if password, err = readTrimmedFile(cfg.PasswordFile); err != nil {
+			return nil, fmt.Errorf("unable to read password file: %w", err)
+		}
+	}
+	if cfg.APIKeyFile != "" {
+		if apiKey, err = readTrimmedFile(cfg.APIKeyFile); err != nil {
+			return nil, fmt.Errorf("unable to read api key file: %w", err)
+		}
+	}
+
+	httpClient, err := buildElasticsearchHTTPClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to configure elasticsearch tls: %w", err)
+	}
+	return analysisprovider.NewElasticsearchProvider(httpClient, cfg.Address, cfg.IndexPattern, username, password, apiKey), nil
+}
+
+// buildElasticsearchHTTPClient returns http.DefaultClient unless cfg asks for
+// TLS customization, in which case it returns a client whose transport trusts
+// cfg.CAFile and/or skips verification per cfg.SkipTLSVerify.
+func buildElasticsearchHTTPClient(cfg *config.AnalysisProviderElasticsearchConfig) (*http.Client, error) {
+	if cfg.CAFile == "" && !cfg.SkipTLSVerify {
+		return http.DefaultClient, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.SkipTLSVerify}
+	if cfg.CAFile != "" {
+		caCert, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no valid certificate found in ca file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}, nil
+}
+
+func readTrimmedFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
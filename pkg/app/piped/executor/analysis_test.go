@@ -0,0 +1,46 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+func TestBuildElasticsearchHTTPClient(t *testing.T) {
+	t.Run("no tls customization returns the default client", func(t *testing.T) {
+		client, err := buildElasticsearchHTTPClient(&config.AnalysisProviderElasticsearchConfig{})
+		require.NoError(t, err)
+		assert.Same(t, http.DefaultClient, client)
+	})
+
+	t.Run("skip tls verify builds a custom transport", func(t *testing.T) {
+		client, err := buildElasticsearchHTTPClient(&config.AnalysisProviderElasticsearchConfig{SkipTLSVerify: true})
+		require.NoError(t, err)
+		transport, ok := client.Transport.(*http.Transport)
+		require.True(t, ok)
+		assert.True(t, transport.TLSClientConfig.InsecureSkipVerify)
+	})
+
+	t.Run("invalid ca file errors", func(t *testing.T) {
+		_, err := buildElasticsearchHTTPClient(&config.AnalysisProviderElasticsearchConfig{CAFile: "/does/not/exist"})
+		assert.Error(t, err)
+	})
+}
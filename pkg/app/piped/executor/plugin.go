@@ -0,0 +1,255 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	goplugin "github.com/hashicorp/go-plugin"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/model"
+	"github.com/pipe-cd/pipe/pkg/plugin"
+)
+
+// handshakeConfig must match the one compiled into conforming third-party
+// plugin binaries or go-plugin will refuse the connection.
+var handshakeConfig = goplugin.HandshakeConfig{
+	ProtocolVersion:  1,
+	MagicCookieKey:   "PIPED_EXECUTOR_PLUGIN",
+	MagicCookieValue: "pipe-cd",
+}
+
+// restartBackoff is the delay observed between a plugin subprocess being
+// found unhealthy and it being restarted.
+const restartBackoff = 2 * time.Second
+
+// healthCheckInterval is how often a running plugin subprocess is polled for health.
+const healthCheckInterval = 15 * time.Second
+
+// pluginProcess manages the lifecycle of a single external executor plugin
+// subprocess: starting it on demand, health-checking it on a timer,
+// restarting it if it crashes or fails its health check, and shutting it
+// down gracefully when Piped exits.
+type pluginProcess struct {
+	name string
+	spec config.PipedPlugin
+
+	mu      sync.Mutex
+	process *goplugin.Client
+	stub    plugin.ExecutorClient
+
+	monitorCancel context.CancelFunc
+}
+
+func startPluginProcess(spec config.PipedPlugin) (*pluginProcess, error) {
+	p := &pluginProcess{name: spec.Name, spec: spec}
+	if err := p.ensureStarted(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.monitorCancel = cancel
+	go p.monitor(ctx)
+
+	return p, nil
+}
+
+func (p *pluginProcess) ensureStarted() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.process != nil && !p.process.Exited() {
+		return nil
+	}
+
+	cmd, err := pluginCommand(p.spec)
+	if err != nil {
+		return err
+	}
+
+	p.process = goplugin.NewClient(&goplugin.ClientConfig{
+		HandshakeConfig: handshakeConfig,
+		Plugins: map[string]goplugin.Plugin{
+			"executor": &plugin.ExecutorGRPCPlugin{},
+		},
+		Cmd:              cmd,
+		AllowedProtocols: []goplugin.Protocol{goplugin.ProtocolGRPC},
+	})
+
+	rpcClient, err := p.process.Client()
+	if err != nil {
+		return fmt.Errorf("unable to connect to plugin %s: %w", p.name, err)
+	}
+	raw, err := rpcClient.Dispense("executor")
+	if err != nil {
+		return fmt.Errorf("unable to dispense executor plugin %s: %w", p.name, err)
+	}
+	stub, ok := raw.(plugin.ExecutorClient)
+	if !ok {
+		return fmt.Errorf("plugin %s does not implement the executor contract", p.name)
+	}
+	p.stub = stub
+	return nil
+}
+
+// client returns a live gRPC stub, restarting the subprocess first if it has crashed.
+func (p *pluginProcess) client() (plugin.ExecutorClient, error) {
+	if err := p.ensureStarted(); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stub, nil
+}
+
+func (p *pluginProcess) stop() {
+	if p.monitorCancel != nil {
+		p.monitorCancel()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.process != nil {
+		p.process.Kill()
+	}
+}
+
+// monitor periodically health-checks the plugin subprocess and restarts it
+// whenever it reports unhealthy or becomes unreachable, until ctx is done.
+func (p *pluginProcess) monitor(ctx context.Context) {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.checkHealthAndRestartIfNeeded(ctx)
+		}
+	}
+}
+
+func (p *pluginProcess) checkHealthAndRestartIfNeeded(ctx context.Context) {
+	stub, err := p.client()
+	if err == nil {
+		healthy, herr := stub.HealthCheck(ctx)
+		if herr == nil && healthy {
+			return
+		}
+	}
+
+	p.mu.Lock()
+	if p.process != nil {
+		p.process.Kill()
+	}
+	p.mu.Unlock()
+
+	time.Sleep(restartBackoff)
+	// A failure here is not fatal: the next health check tick, or the next
+	// Execute call through client(), will retry starting the subprocess.
+	_ = p.ensureStarted()
+}
+
+func pluginCommand(spec config.PipedPlugin) (*exec.Cmd, error) {
+	if spec.BinaryPath != "" {
+		return exec.Command(spec.BinaryPath), nil
+	}
+	if spec.OCIImage != "" {
+		// OCI images are not pulled or extracted anywhere in Piped, so a
+		// plugin configured with ociImage and no binaryPath fails to load.
+		return nil, fmt.Errorf("plugin %s: ociImage must be resolved to a binaryPath before loading", spec.Name)
+	}
+	return nil, fmt.Errorf("plugin %s: no binaryPath or ociImage configured", spec.Name)
+}
+
+// proxyExecutor forwards Execute, log persisting and cancellation to an
+// out-of-process plugin over gRPC, so it can be returned from
+// Registry.Executor exactly like an in-process Executor.
+type proxyExecutor struct {
+	process *pluginProcess
+	in      Input
+}
+
+func newProxyExecutor(p *pluginProcess, in Input) Executor {
+	return &proxyExecutor{process: p, in: in}
+}
+
+// Execute forwards the stage execution request to the plugin subprocess and
+// streams its log output back through the same LogPersister an in-process
+// executor would use, until the plugin reports completion or sig is terminated.
+func (e *proxyExecutor) Execute(sig StopSignal) model.StageStatus {
+	stub, err := e.process.client()
+	if err != nil {
+		e.in.LogPersister.Errorf("unable to reach plugin %s: %v", e.process.name, err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	req, err := e.buildExecuteRequest()
+	if err != nil {
+		e.in.LogPersister.Errorf("unable to build request for plugin %s: %v", e.process.name, err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	status, err := stub.Execute(sig.Context(), req, func(line string) {
+		e.in.LogPersister.Info(line)
+	})
+	if err != nil {
+		e.in.LogPersister.Errorf("plugin %s returned an error: %v", e.process.name, err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+	return toModelStageStatus(status)
+}
+
+// buildExecuteRequest translates this executor's Input into the serializable
+// request the plugin contract expects.
+func (e *proxyExecutor) buildExecuteRequest() (plugin.ExecuteRequest, error) {
+	stageConfig, err := json.Marshal(e.in.StageConfig)
+	if err != nil {
+		return plugin.ExecuteRequest{}, fmt.Errorf("unable to marshal stage config: %w", err)
+	}
+	deployment, err := json.Marshal(e.in.Deployment)
+	if err != nil {
+		return plugin.ExecuteRequest{}, fmt.Errorf("unable to marshal deployment: %w", err)
+	}
+	return plugin.ExecuteRequest{
+		ExecutionId:  e.in.Stage.Id,
+		StageName:    e.in.Stage.Name,
+		StageConfig:  stageConfig,
+		Deployment:   deployment,
+		PluginConfig: e.process.spec.Config,
+	}, nil
+}
+
+// toModelStageStatus maps the plugin contract's StageStatus onto the
+// executor package's own model.StageStatus; the two enums are defined in
+// different packages and their numeric values are not interchangeable.
+func toModelStageStatus(s plugin.StageStatus) model.StageStatus {
+	switch s {
+	case plugin.StageSuccess:
+		return model.StageStatus_STAGE_SUCCESS
+	case plugin.StageCancelled:
+		return model.StageStatus_STAGE_CANCELLED
+	case plugin.StageFailure:
+		return model.StageStatus_STAGE_FAILURE
+	default:
+		return model.StageStatus_STAGE_FAILURE
+	}
+}
@@ -18,16 +18,24 @@ import (
 	"fmt"
 	"sync"
 
-	"github.com/kapetaniosci/pipe/pkg/model"
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/model"
 )
 
 type Registry interface {
 	Register(stage model.Stage, f Factory) error
 	Executor(stage model.Stage, in Input) (Executor, error)
+	// LoadPlugins starts the external executor plugins declared in the given
+	// list and registers them so that Executor can transparently proxy to
+	// them for the stages they handle.
+	LoadPlugins(plugins []config.PipedPlugin) error
+	// Stop gracefully shuts down all running plugin processes.
+	Stop()
 }
 
 type registry struct {
 	factories map[model.Stage]Factory
+	plugins   map[model.Stage]*pluginProcess
 	mu        sync.RWMutex
 }
 
@@ -44,16 +52,68 @@ func (r *registry) Register(stage model.Stage, f Factory) error {
 
 func (r *registry) Executor(stage model.Stage, in Input) (Executor, error) {
 	r.mu.RLock()
-	defer r.mu.RUnlock()
 	f, ok := r.factories[stage]
-	if !ok {
-		return nil, fmt.Errorf("no registered executor for stage %s", stage)
+	r.mu.RUnlock()
+	if ok {
+		return f(in), nil
 	}
-	return f(in), nil
+
+	r.mu.RLock()
+	p, ok := r.plugins[stage]
+	r.mu.RUnlock()
+	if ok {
+		return newProxyExecutor(p, in), nil
+	}
+
+	return nil, fmt.Errorf("no registered executor for stage %s", stage)
 }
 
-var defaultRegistry = &registry{}
+func (r *registry) LoadPlugins(plugins []config.PipedPlugin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.plugins == nil {
+		r.plugins = make(map[model.Stage]*pluginProcess)
+	}
+
+	for _, spec := range plugins {
+		p, err := startPluginProcess(spec)
+		if err != nil {
+			return fmt.Errorf("unable to start plugin %s: %w", spec.Name, err)
+		}
+		for _, s := range spec.Stages {
+			stage := model.Stage(s)
+			if _, ok := r.factories[stage]; ok {
+				return fmt.Errorf("executor for %s stage has already registered", stage)
+			}
+			if existing, ok := r.plugins[stage]; ok {
+				return fmt.Errorf("executor for %s stage has already been registered by plugin %s", stage, existing.name)
+			}
+			r.plugins[stage] = p
+		}
+	}
+	return nil
+}
+
+func (r *registry) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stopped := make(map[string]struct{}, len(r.plugins))
+	for _, p := range r.plugins {
+		if _, ok := stopped[p.name]; ok {
+			continue
+		}
+		stopped[p.name] = struct{}{}
+		p.stop()
+	}
+}
+
+var defaultRegistry = &registry{
+	factories: make(map[model.Stage]Factory),
+	plugins:   make(map[model.Stage]*pluginProcess),
+}
 
 func DefaultRegistry() Registry {
 	return defaultRegistry
-}
\ No newline at end of file
+}
@@ -0,0 +1,200 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package notifier sends deployment event notifications to the receivers
+// configured for a Piped.
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+// webhookSender posts notification payloads to a single configured
+// NotificationReceiverWebhook, applying whichever authentication scheme it
+// was configured with.
+type webhookSender struct {
+	cfg    config.NotificationReceiverWebhook
+	client *http.Client
+
+	signingKey []byte
+	bearer     string
+	username   string
+	password   string
+
+	tokenSource oauth2.TokenSource
+}
+
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func newWebhookSender(cfg config.NotificationReceiverWebhook) (*webhookSender, error) {
+	s := &webhookSender{cfg: cfg}
+
+	tlsConfig := &tls.Config{}
+	if cfg.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read webhook caFile: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("unable to parse webhook caFile")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.ClientCertFile != "" || cfg.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCertFile, cfg.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load webhook client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	s.client = &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+
+	if cfg.SigningKeyFile != "" {
+		key, err := readSecretFile(cfg.SigningKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read webhook signingKeyFile: %w", err)
+		}
+		s.signingKey = []byte(key)
+	}
+	if cfg.BearerTokenFile != "" {
+		token, err := readSecretFile(cfg.BearerTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read webhook bearerTokenFile: %w", err)
+		}
+		s.bearer = token
+	}
+	if cfg.BasicAuth != nil {
+		username, err := readSecretFile(cfg.BasicAuth.UsernameFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read webhook basicAuth usernameFile: %w", err)
+		}
+		password, err := readSecretFile(cfg.BasicAuth.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read webhook basicAuth passwordFile: %w", err)
+		}
+		s.username = username
+		s.password = password
+	}
+	if cfg.OIDC != nil {
+		secret, err := readSecretFile(cfg.OIDC.ClientSecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read webhook oidc clientSecretFile: %w", err)
+		}
+		conf := &clientcredentials.Config{
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: secret,
+			TokenURL:     cfg.OIDC.IssuerURL + "/token",
+			Scopes:       cfg.OIDC.Scopes,
+			EndpointParams: map[string][]string{
+				"audience": {cfg.OIDC.Audience},
+			},
+		}
+		// TokenSource wraps an oauth2.ReuseTokenSource, so the client-credentials
+		// token is only re-fetched once it's near expiry instead of on every Send.
+		s.tokenSource = conf.TokenSource(context.Background())
+	}
+
+	return s, nil
+}
+
+// Send posts payload to the configured endpoint, applying signing, bearer,
+// basic, mTLS and/or OIDC auth as configured.
+func (s *webhookSender) Send(ctx context.Context, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	if len(s.signingKey) > 0 {
+		s.sign(req, payload)
+	}
+	if s.bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+s.bearer)
+	}
+	if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+	if s.tokenSource != nil {
+		token, err := s.oidcToken()
+		if err != nil {
+			return fmt.Errorf("unable to obtain oidc token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send webhook notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook notification rejected with status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// sign computes an HMAC-SHA256 over "timestamp.payload" and sets it as the
+// X-Pipe-Signature header, together with the timestamp used, so the
+// receiver can reject requests whose timestamp is too old as a replay.
+func (s *webhookSender) sign(req *http.Request, payload []byte) {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	sig := hex.EncodeToString(mac.Sum(nil))
+
+	req.Header.Set("X-Pipe-Timestamp", ts)
+	req.Header.Set("X-Pipe-Signature", sig)
+}
+
+func (s *webhookSender) oidcToken() (string, error) {
+	token, err := s.tokenSource.Token()
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
@@ -59,6 +59,8 @@ type PipedSpec struct {
 	Notifications Notifications `json:"notifications"`
 	// How the sealed secret should be managed.
 	SealedSecretManagement *SealedSecretManagement `json:"sealedSecretManagement"`
+	// List of external executor plugins loaded by this piped.
+	Plugins []PipedPlugin `json:"plugins"`
 }
 
 // Validate validates configured data of all fields.
@@ -86,6 +88,11 @@ func (s *PipedSpec) Validate() error {
 			return err
 		}
 	}
+	for _, p := range s.Plugins {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("invalid plugin %s: %w", p.Name, err)
+		}
+	}
 	return nil
 }
 
@@ -156,6 +163,35 @@ func (s *PipedSpec) GetAnalysisProvider(name string) (PipedAnalysisProvider, boo
 	return PipedAnalysisProvider{}, false
 }
 
+// PipedPlugin configures an out-of-process executor plugin, allowing third
+// parties to add support for new deployment targets without forking Piped.
+type PipedPlugin struct {
+	// The unique name of this plugin.
+	Name string `json:"name"`
+	// The path to the plugin binary on disk. Mutually exclusive with OCIImage.
+	BinaryPath string `json:"binaryPath"`
+	// The OCI image providing the plugin binary. Mutually exclusive with BinaryPath.
+	// The image is not pulled by Piped: a plugin that sets only OCIImage fails to load.
+	OCIImage string `json:"ociImage"`
+	// List of stage names this plugin handles, e.g. ["NOMAD_SYNC"].
+	Stages []string `json:"stages"`
+	// Arbitrary plugin-specific configuration, passed through as-is.
+	Config json.RawMessage `json:"config"`
+}
+
+func (p PipedPlugin) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("name must be set")
+	}
+	if p.BinaryPath == "" && p.OCIImage == "" {
+		return fmt.Errorf("either binaryPath or ociImage must be set")
+	}
+	if len(p.Stages) == 0 {
+		return fmt.Errorf("stages must not be empty")
+	}
+	return nil
+}
+
 type PipedGit struct {
 	// The username that will be configured for `git` user.
 	Username string `json:"username"`
@@ -269,13 +305,39 @@ type CloudProviderKubernetesConfig struct {
 type KubernetesAppStateInformer struct {
 	// Only watches the specified namespace.
 	// Empty means watching all namespaces.
+	// Deprecated: use Namespaces instead.
 	Namespace string `json:"namespace"`
+	// Only watches the specified namespaces.
+	// Empty means watching all namespaces.
+	Namespaces []string `json:"namespaces"`
+	// A Kubernetes label selector expression used to narrow down the
+	// watching targets on the server side, e.g. "app.kubernetes.io/managed-by=piped".
+	LabelSelector string `json:"labelSelector"`
+	// A Kubernetes field selector expression used to narrow down the
+	// watching targets on the server side.
+	FieldSelector string `json:"fieldSelector"`
+	// How often the shared informers should do a full relist.
+	// Default is 30m.
+	ResyncPeriod Duration `json:"resyncPeriod"`
 	// List of resources that should be added to the watching targets.
 	IncludeResources []KubernetesResourceMatcher `json:"includeResources"`
 	// List of resources that should be ignored from the watching targets.
 	ExcludeResources []KubernetesResourceMatcher `json:"excludeResources"`
 }
 
+// WatchNamespaces returns the effective list of namespaces to watch, folding
+// the deprecated singular Namespace field into the plural one. An empty
+// result means all namespaces should be watched.
+func (i KubernetesAppStateInformer) WatchNamespaces() []string {
+	if len(i.Namespaces) > 0 {
+		return i.Namespaces
+	}
+	if i.Namespace != "" {
+		return []string{i.Namespace}
+	}
+	return nil
+}
+
 type KubernetesResourceMatcher struct {
 	// The APIVersion of the kubernetes resource.
 	APIVersion string `json:"apiVersion"`
@@ -310,9 +372,10 @@ type PipedAnalysisProvider struct {
 	Name string                     `json:"name"`
 	Type model.AnalysisProviderType `json:"type"`
 
-	PrometheusConfig  *AnalysisProviderPrometheusConfig  `json:"prometheus"`
-	DatadogConfig     *AnalysisProviderDatadogConfig     `json:"datadog"`
-	StackdriverConfig *AnalysisProviderStackdriverConfig `json:"stackdriver"`
+	PrometheusConfig    *AnalysisProviderPrometheusConfig    `json:"prometheus"`
+	DatadogConfig       *AnalysisProviderDatadogConfig       `json:"datadog"`
+	StackdriverConfig   *AnalysisProviderStackdriverConfig   `json:"stackdriver"`
+	ElasticsearchConfig *AnalysisProviderElasticsearchConfig `json:"elasticsearch"`
 }
 
 type genericPipedAnalysisProvider struct {
@@ -346,6 +409,11 @@ func (p *PipedAnalysisProvider) UnmarshalJSON(data []byte) error {
 		if len(gp.Config) > 0 {
 			err = json.Unmarshal(gp.Config, p.StackdriverConfig)
 		}
+	case model.AnalysisProviderElasticsearch:
+		p.ElasticsearchConfig = &AnalysisProviderElasticsearchConfig{}
+		if len(gp.Config) > 0 {
+			err = json.Unmarshal(gp.Config, p.ElasticsearchConfig)
+		}
 	default:
 		err = fmt.Errorf("unsupported analysis provider type: %s", p.Name)
 	}
@@ -373,21 +441,72 @@ type AnalysisProviderStackdriverConfig struct {
 	ServiceAccountFile string `json:"serviceAccountFile"`
 }
 
+type AnalysisProviderElasticsearchConfig struct {
+	Address string `json:"address"`
+	// The index pattern to query against, e.g. "logs-app-*".
+	IndexPattern string `json:"indexPattern"`
+	// The path to the username file.
+	UsernameFile string `json:"usernameFile"`
+	// The path to the password file.
+	PasswordFile string `json:"passwordFile"`
+	// The path to the API key file. Used instead of username/password when set.
+	APIKeyFile string `json:"apiKeyFile"`
+	// The path to the CA file used to verify the server's certificate.
+	CAFile string `json:"caFile"`
+	// Whether to skip verifying the server's certificate.
+	SkipTLSVerify bool `json:"skipTLSVerify"`
+}
+
+// ImageProviderMode represents how Piped discovers new image tags for a provider.
+type ImageProviderMode string
+
+const (
+	// ImageProviderModePoll periodically lists tags using PullInterval. This is the default.
+	ImageProviderModePoll ImageProviderMode = "poll"
+	// ImageProviderModeWebhook relies solely on registry push events delivered to the webhook receiver.
+	ImageProviderModeWebhook ImageProviderMode = "webhook"
+	// ImageProviderModeHybrid uses webhook events for fast detection while keeping polling as a fallback.
+	ImageProviderModeHybrid ImageProviderMode = "hybrid"
+)
+
 type PipedImageProvider struct {
 	Name string                  `json:"name"`
 	Type model.ImageProviderType `json:"type"`
 	// Default is five minute.
 	PullInterval Duration `json:"pullInterval"`
+	// How new tags are discovered: poll, webhook or hybrid.
+	// Default is poll.
+	Mode ImageProviderMode `json:"mode"`
+	// Configuration for the webhook receiver used in webhook/hybrid mode.
+	WebhookConfig *ImageProviderWebhookConfig `json:"webhook"`
+	// Whether Piped should resolve the tag to its immutable digest and write
+	// back "image@sha256:..." to git instead of the mutable tag.
+	DigestPinning bool `json:"digestPinning"`
 
 	DockerhubConfig *ImageProviderDockerhubConfig
 	GCRConfig       *ImageProviderGCRConfig
 	ECRConfig       *ImageProviderECRConfig
+	ACRConfig       *ImageProviderACRConfig
+	HarborConfig    *ImageProviderHarborConfig
+}
+
+type ImageProviderWebhookConfig struct {
+	// The path this provider's events are delivered to, mounted under Piped's HTTP server.
+	Path string `json:"path"`
+	// The path to the file containing the HMAC secret used to verify the payload signature.
+	SignatureSecretFile string `json:"signatureSecretFile"`
+	// List of CIDRs the webhook request's source IP must belong to.
+	// Empty means no restriction.
+	AllowedSourceCIDRs []string `json:"allowedSourceCIDRs"`
 }
 
 type genericPipedImageProvider struct {
-	Name         string                  `json:"name"`
-	Type         model.ImageProviderType `json:"type"`
-	PullInterval Duration                `json:"pullInterval"`
+	Name          string                      `json:"name"`
+	Type          model.ImageProviderType     `json:"type"`
+	PullInterval  Duration                    `json:"pullInterval"`
+	Mode          ImageProviderMode           `json:"mode"`
+	WebhookConfig *ImageProviderWebhookConfig `json:"webhook"`
+	DigestPinning bool                        `json:"digestPinning"`
 
 	Config json.RawMessage `json:"config"`
 }
@@ -404,6 +523,12 @@ func (p *PipedImageProvider) UnmarshalJSON(data []byte) error {
 	if p.PullInterval == 0 {
 		p.PullInterval = Duration(time.Minute * 5)
 	}
+	p.Mode = gp.Mode
+	if p.Mode == "" {
+		p.Mode = ImageProviderModePoll
+	}
+	p.WebhookConfig = gp.WebhookConfig
+	p.DigestPinning = gp.DigestPinning
 
 	switch p.Type {
 	case model.ImageProviderTypeDockerhub:
@@ -421,6 +546,16 @@ func (p *PipedImageProvider) UnmarshalJSON(data []byte) error {
 		if len(gp.Config) > 0 {
 			err = json.Unmarshal(gp.Config, p.ECRConfig)
 		}
+	case model.ImageProviderTypeACR:
+		p.ACRConfig = &ImageProviderACRConfig{}
+		if len(gp.Config) > 0 {
+			err = json.Unmarshal(gp.Config, p.ACRConfig)
+		}
+	case model.ImageProviderTypeHarbor:
+		p.HarborConfig = &ImageProviderHarborConfig{}
+		if len(gp.Config) > 0 {
+			err = json.Unmarshal(gp.Config, p.HarborConfig)
+		}
 	default:
 		err = fmt.Errorf("unsupported image provider type: %s", p.Name)
 	}
@@ -439,6 +574,37 @@ type ImageProviderDockerhubConfig struct {
 type ImageProviderECRConfig struct {
 }
 
+type ImageProviderACRConfig struct {
+	// The login server of the registry, e.g. "myregistry.azurecr.io".
+	LoginServer string `json:"loginServer"`
+	// The Azure AD tenant ID.
+	TenantID string `json:"tenantId"`
+	// The Azure AD application (client) ID.
+	ClientID string `json:"clientId"`
+	// The path to the file containing the client secret.
+	// Not needed when UseWorkloadIdentity is true.
+	ClientSecretFile string `json:"clientSecretFile"`
+	// Whether to authenticate using Azure AD workload identity instead of a client secret.
+	UseWorkloadIdentity bool `json:"useWorkloadIdentity"`
+}
+
+type ImageProviderHarborConfig struct {
+	// The address of the Harbor instance, e.g. "https://harbor.example.com".
+	Address string `json:"address"`
+	// The Harbor project name.
+	Project string `json:"project"`
+	// The path to the username file.
+	UsernameFile string `json:"usernameFile"`
+	// The path to the password file.
+	PasswordFile string `json:"passwordFile"`
+	// The path to a Harbor robot account token file, used instead of username/password when set.
+	RobotAccountFile string `json:"robotAccountFile"`
+	// The path to the CA file used to verify the server's certificate.
+	CAFile string `json:"caFile"`
+	// Whether to skip verifying the server's certificate.
+	SkipTLSVerify bool `json:"skipTLSVerify"`
+}
+
 type Notifications struct {
 	// List of notification routes.
 	Routes []NotificationRoute `json:"routes"`
@@ -471,6 +637,45 @@ type NotificationReceiverSlack struct {
 
 type NotificationReceiverWebhook struct {
 	URL string `json:"url"`
+	// The path to a file containing the HMAC-SHA256 signing key. When set,
+	// every request carries an X-Pipe-Signature header computed over the
+	// payload together with a timestamp, to prevent replay.
+	SigningKeyFile string `json:"signingKeyFile"`
+	// The path to a file containing a bearer token sent as the Authorization header.
+	BearerTokenFile string `json:"bearerTokenFile"`
+	// HTTP basic authentication credentials.
+	BasicAuth *NotificationReceiverWebhookBasicAuth `json:"basicAuth"`
+	// The path to the CA file used to verify the endpoint's certificate.
+	CAFile string `json:"caFile"`
+	// The path to the client certificate file used for mTLS.
+	ClientCertFile string `json:"clientCertFile"`
+	// The path to the client private key file used for mTLS.
+	ClientKeyFile string `json:"clientKeyFile"`
+	// OIDC client-credentials configuration used to obtain a short-lived
+	// bearer token before each request.
+	OIDC *NotificationReceiverWebhookOIDC `json:"oidc"`
+	// Extra headers sent on every request, e.g. for gateway routing.
+	Headers map[string]string `json:"headers"`
+}
+
+type NotificationReceiverWebhookBasicAuth struct {
+	// The path to the username file.
+	UsernameFile string `json:"usernameFile"`
+	// The path to the password file.
+	PasswordFile string `json:"passwordFile"`
+}
+
+type NotificationReceiverWebhookOIDC struct {
+	// The URL of the OIDC issuer.
+	IssuerURL string `json:"issuerURL"`
+	// The OAuth2 client ID.
+	ClientID string `json:"clientID"`
+	// The path to the file containing the OAuth2 client secret.
+	ClientSecretFile string `json:"clientSecretFile"`
+	// The audience to request the token for.
+	Audience string `json:"audience"`
+	// The scopes to request.
+	Scopes []string `json:"scopes"`
 }
 
 type SealedSecretManagement struct {
@@ -480,6 +685,7 @@ type SealedSecretManagement struct {
 
 	SealingKeyConfig *SealedSecretManagementSealingKey
 	GCPKMSConfig     *SealedSecretManagementGCPKMS
+	VaultConfig      *SealedSecretManagementVault
 }
 
 func (m *SealedSecretManagement) Validate() error {
@@ -488,6 +694,8 @@ func (m *SealedSecretManagement) Validate() error {
 		return m.SealingKeyConfig.Validate()
 	case model.SealedSecretManagementGCPKMS:
 		return m.GCPKMSConfig.Validate()
+	case model.SealedSecretManagementVault:
+		return m.VaultConfig.Validate()
 	default:
 		return fmt.Errorf("unsupported sealed secret management type: %s", m.Type)
 	}
@@ -534,6 +742,81 @@ func (m *SealedSecretManagementGCPKMS) Validate() error {
 	return nil
 }
 
+// SealedSecretManagementVaultAuthMethod represents the way Piped authenticates to Vault.
+type SealedSecretManagementVaultAuthMethod string
+
+const (
+	SealedSecretManagementVaultAuthToken      SealedSecretManagementVaultAuthMethod = "token"
+	SealedSecretManagementVaultAuthAppRole    SealedSecretManagementVaultAuthMethod = "approle"
+	SealedSecretManagementVaultAuthKubernetes SealedSecretManagementVaultAuthMethod = "kubernetes"
+)
+
+type SealedSecretManagementVault struct {
+	// Configurable fields when using HashiCorp Vault.
+	// The address of the Vault server, e.g. https://vault.example.com:8200.
+	Address string `json:"address"`
+	// The Vault namespace to use. Only meaningful for Vault Enterprise.
+	Namespace string `json:"namespace"`
+	// The mount path of the Transit secrets engine.
+	// Default is "transit".
+	TransitMountPath string `json:"transitMountPath"`
+	// The name of the Transit key used to encrypt/decrypt.
+	KeyName string `json:"keyName"`
+	// Which auth method should be used to log in to Vault.
+	// Available values: token, approle, kubernetes.
+	AuthMethod SealedSecretManagementVaultAuthMethod `json:"authMethod"`
+	// The path to the file containing the Vault token. Used when AuthMethod is "token".
+	TokenFile string `json:"tokenFile"`
+	// The path to the file containing the AppRole role_id. Used when AuthMethod is "approle".
+	RoleIDFile string `json:"roleIdFile"`
+	// The path to the file containing the AppRole secret_id. Used when AuthMethod is "approle".
+	SecretIDFile string `json:"secretIdFile"`
+	// The path to the Kubernetes service account token file. Used when AuthMethod is "kubernetes".
+	// Default is "/var/run/secrets/kubernetes.io/serviceaccount/token".
+	ServiceAccountTokenFile string `json:"serviceAccountTokenFile"`
+	// The name of the Vault role to log in as. Used when AuthMethod is "kubernetes".
+	Role string `json:"role"`
+	// The path to the CA file used to verify the Vault server's certificate.
+	CAFile string `json:"caFile"`
+	// Whether to skip verifying the Vault server's certificate.
+	SkipTLSVerify bool `json:"skipTLSVerify"`
+}
+
+func (m *SealedSecretManagementVault) Validate() error {
+	if m.Address == "" {
+		return fmt.Errorf("address must be set")
+	}
+	if m.KeyName == "" {
+		return fmt.Errorf("keyName must be set")
+	}
+	if m.TransitMountPath == "" {
+		m.TransitMountPath = "transit"
+	}
+	switch m.AuthMethod {
+	case SealedSecretManagementVaultAuthToken:
+		if m.TokenFile == "" {
+			return fmt.Errorf("tokenFile must be set")
+		}
+	case SealedSecretManagementVaultAuthAppRole:
+		if m.RoleIDFile == "" {
+			return fmt.Errorf("roleIdFile must be set")
+		}
+		if m.SecretIDFile == "" {
+			return fmt.Errorf("secretIdFile must be set")
+		}
+	case SealedSecretManagementVaultAuthKubernetes:
+		if m.Role == "" {
+			return fmt.Errorf("role must be set")
+		}
+		if m.ServiceAccountTokenFile == "" {
+			m.ServiceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+		}
+	default:
+		return fmt.Errorf("unsupported vault auth method: %s", m.AuthMethod)
+	}
+	return nil
+}
+
 type genericSealedSecretManagement struct {
 	Type   model.SealedSecretManagementType `json:"type"`
 	Config json.RawMessage                  `json:"config"`
@@ -558,6 +841,11 @@ func (p *SealedSecretManagement) UnmarshalJSON(data []byte) error {
 		if len(g.Config) > 0 {
 			err = json.Unmarshal(g.Config, p.GCPKMSConfig)
 		}
+	case model.SealedSecretManagementVault:
+		p.VaultConfig = &SealedSecretManagementVault{}
+		if len(g.Config) > 0 {
+			err = json.Unmarshal(g.Config, p.VaultConfig)
+		}
 	default:
 		err = fmt.Errorf("unsupported sealed secret management type: %s", p.Type)
 	}
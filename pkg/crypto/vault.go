@@ -0,0 +1,223 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crypto
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+// VaultSealer seals and unseals secrets using a Vault Transit key. It
+// implements the same Sealer/Decrypter contract as the SealingKey and GCP
+// KMS backends.
+type VaultSealer struct {
+	client    *vaultapi.Client
+	mountPath string
+	keyName   string
+	role      string
+
+	renewStop chan struct{}
+}
+
+// NewVaultSealer builds a VaultSealer and logs Piped in to Vault using the
+// auth method selected in cfg.
+func NewVaultSealer(ctx context.Context, cfg *config.SealedSecretManagementVault) (*VaultSealer, error) {
+	vc := vaultapi.DefaultConfig()
+	vc.Address = cfg.Address
+	if cfg.SkipTLSVerify || cfg.CAFile != "" {
+		if err := vc.ConfigureTLS(&vaultapi.TLSConfig{
+			CACert:   cfg.CAFile,
+			Insecure: cfg.SkipTLSVerify,
+		}); err != nil {
+			return nil, fmt.Errorf("unable to configure vault tls: %w", err)
+		}
+	}
+
+	client, err := vaultapi.NewClient(vc)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create vault client: %w", err)
+	}
+	if cfg.Namespace != "" {
+		client.SetNamespace(cfg.Namespace)
+	}
+
+	v := &VaultSealer{
+		client:    client,
+		mountPath: cfg.TransitMountPath,
+		keyName:   cfg.KeyName,
+		role:      cfg.Role,
+	}
+
+	secret, err := v.login(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if secret != nil && secret.Auth != nil && secret.Auth.Renewable {
+		v.renewStop = make(chan struct{})
+		go v.renewLoop(secret)
+	}
+	return v, nil
+}
+
+// Seal encrypts the given plain text through Vault's Transit encrypt endpoint.
+func (v *VaultSealer) Seal(ctx context.Context, plain string) (string, error) {
+	var out string
+	err := v.withRetry(func() error {
+		secret, err := v.client.Logical().WriteWithContext(ctx, fmt.Sprintf("%s/encrypt/%s", v.mountPath, v.keyName), map[string]interface{}{
+			"plaintext": base64Encode(plain),
+		})
+		if err != nil {
+			return err
+		}
+		ct, ok := secret.Data["ciphertext"].(string)
+		if !ok {
+			return fmt.Errorf("unexpected response from vault: missing ciphertext")
+		}
+		out = ct
+		return nil
+	})
+	return out, err
+}
+
+// Decrypt decrypts the given Vault ciphertext through the Transit decrypt endpoint.
+func (v *VaultSealer) Decrypt(ctx context.Context, sealed string) (string, error) {
+	var out string
+	err := v.withRetry(func() error {
+		secret, err := v.client.Logical().WriteWithContext(ctx, fmt.Sprintf("%s/decrypt/%s", v.mountPath, v.keyName), map[string]interface{}{
+			"ciphertext": sealed,
+		})
+		if err != nil {
+			return err
+		}
+		pt, ok := secret.Data["plaintext"].(string)
+		if !ok {
+			return fmt.Errorf("unexpected response from vault: missing plaintext")
+		}
+		plain, err := base64Decode(pt)
+		if err != nil {
+			return err
+		}
+		out = plain
+		return nil
+	})
+	return out, err
+}
+
+// Close stops the background token-renewal loop, if any.
+func (v *VaultSealer) Close() {
+	if v.renewStop != nil {
+		close(v.renewStop)
+	}
+}
+
+func (v *VaultSealer) login(ctx context.Context, cfg *config.SealedSecretManagementVault) (*vaultapi.Secret, error) {
+	switch cfg.AuthMethod {
+	case config.SealedSecretManagementVaultAuthToken:
+		token, err := readFile(cfg.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read vault token file: %w", err)
+		}
+		v.client.SetToken(token)
+		return nil, nil
+
+	case config.SealedSecretManagementVaultAuthAppRole:
+		roleID, err := readFile(cfg.RoleIDFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read vault role id file: %w", err)
+		}
+		secretID, err := readFile(cfg.SecretIDFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read vault secret id file: %w", err)
+		}
+		secret, err := v.client.Logical().WriteWithContext(ctx, "auth/approle/login", map[string]interface{}{
+			"role_id":   roleID,
+			"secret_id": secretID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to login to vault with approle: %w", err)
+		}
+		v.client.SetToken(secret.Auth.ClientToken)
+		return secret, nil
+
+	case config.SealedSecretManagementVaultAuthKubernetes:
+		jwt, err := readFile(cfg.ServiceAccountTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read vault kubernetes service account token file: %w", err)
+		}
+		secret, err := v.client.Logical().WriteWithContext(ctx, "auth/kubernetes/login", map[string]interface{}{
+			"jwt":  jwt,
+			"role": v.role,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to login to vault with kubernetes auth: %w", err)
+		}
+		v.client.SetToken(secret.Auth.ClientToken)
+		return secret, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported vault auth method: %s", cfg.AuthMethod)
+	}
+}
+
+// renewLoop keeps the login token alive for as long as Vault allows it to be renewed.
+func (v *VaultSealer) renewLoop(secret *vaultapi.Secret) {
+	watcher, err := v.client.NewLifetimeWatcher(&vaultapi.LifetimeWatcherInput{Secret: secret})
+	if err != nil {
+		return
+	}
+	go watcher.Start()
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-v.renewStop:
+			return
+		case err := <-watcher.DoneCh():
+			if err != nil {
+				return
+			}
+		case <-watcher.RenewCh():
+		}
+	}
+}
+
+// withRetry retries transient (5xx) Vault errors with exponential backoff.
+func (v *VaultSealer) withRetry(fn func() error) error {
+	const maxAttempts = 5
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if !isRetryableVaultError(err) {
+			return err
+		}
+		time.Sleep(time.Duration(math.Pow(2, float64(attempt))) * 100 * time.Millisecond)
+	}
+	return err
+}
+
+func isRetryableVaultError(err error) bool {
+	if rerr, ok := err.(*vaultapi.ResponseError); ok {
+		return rerr.StatusCode >= 500
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crypto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+func TestIsRetryableVaultError(t *testing.T) {
+	assert.False(t, isRetryableVaultError(errors.New("boom")))
+	assert.False(t, isRetryableVaultError(&vaultapi.ResponseError{StatusCode: http.StatusBadRequest}))
+	assert.True(t, isRetryableVaultError(&vaultapi.ResponseError{StatusCode: http.StatusServiceUnavailable}))
+}
+
+func TestWithRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	sealer := &VaultSealer{}
+	attempts := 0
+	err := sealer.withRetry(func() error {
+		attempts++
+		return &vaultapi.ResponseError{StatusCode: http.StatusServiceUnavailable}
+	})
+	require.Error(t, err)
+	assert.Equal(t, 5, attempts)
+}
+
+func TestWithRetryStopsOnNonRetryableError(t *testing.T) {
+	sealer := &VaultSealer{}
+	attempts := 0
+	wantErr := errors.New("not found")
+	err := sealer.withRetry(func() error {
+		attempts++
+		return wantErr
+	})
+	assert.Equal(t, wantErr, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestKubernetesAuthLoginUsesConfiguredRole(t *testing.T) {
+	var gotRole string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Role string `json:"role"`
+			JWT  string `json:"jwt"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		gotRole = body.Role
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": "test-token"},
+		})
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "sa-token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("fake-jwt"), 0o600))
+
+	cfg := &config.SealedSecretManagementVault{
+		Address:                 server.URL,
+		AuthMethod:              config.SealedSecretManagementVaultAuthKubernetes,
+		Role:                    "piped-role",
+		ServiceAccountTokenFile: tokenFile,
+	}
+
+	sealer, err := NewVaultSealer(context.Background(), cfg)
+	require.NoError(t, err)
+	defer sealer.Close()
+
+	assert.Equal(t, "piped-role", gotRole)
+}
+
+func TestTokenAuthLoginUsesTokenFileContents(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("s.my-token\n"), 0o600))
+
+	cfg := &config.SealedSecretManagementVault{
+		Address:    "http://127.0.0.1:0",
+		AuthMethod: config.SealedSecretManagementVaultAuthToken,
+		TokenFile:  tokenFile,
+	}
+
+	sealer, err := NewVaultSealer(context.Background(), cfg)
+	require.NoError(t, err)
+	defer sealer.Close()
+
+	assert.Equal(t, "s.my-token", sealer.client.Token())
+}
+
+func TestAppRoleAuthLoginSendsRoleAndSecretID(t *testing.T) {
+	var gotRoleID, gotSecretID string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			RoleID   string `json:"role_id"`
+			SecretID string `json:"secret_id"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		gotRoleID, gotSecretID = body.RoleID, body.SecretID
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": "test-token"},
+		})
+	}))
+	defer server.Close()
+
+	roleIDFile := filepath.Join(t.TempDir(), "role-id")
+	secretIDFile := filepath.Join(t.TempDir(), "secret-id")
+	require.NoError(t, os.WriteFile(roleIDFile, []byte("my-role-id"), 0o600))
+	require.NoError(t, os.WriteFile(secretIDFile, []byte("my-secret-id"), 0o600))
+
+	cfg := &config.SealedSecretManagementVault{
+		Address:      server.URL,
+		AuthMethod:   config.SealedSecretManagementVaultAuthAppRole,
+		RoleIDFile:   roleIDFile,
+		SecretIDFile: secretIDFile,
+	}
+
+	sealer, err := NewVaultSealer(context.Background(), cfg)
+	require.NoError(t, err)
+	defer sealer.Close()
+
+	assert.Equal(t, "my-role-id", gotRoleID)
+	assert.Equal(t, "my-secret-id", gotSecretID)
+}
+
+func TestUnsupportedAuthMethodErrors(t *testing.T) {
+	cfg := &config.SealedSecretManagementVault{
+		Address:    "http://127.0.0.1:0",
+		AuthMethod: "unsupported",
+	}
+	_, err := NewVaultSealer(context.Background(), cfg)
+	assert.Error(t, err)
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imageprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ACRClient lists tags and resolves digests against an Azure Container Registry.
+type ACRClient struct {
+	httpClient  *http.Client
+	loginServer string
+	tokenSource func(ctx context.Context) (string, error)
+}
+
+// NewACRClient creates an ACRClient. tokenSource obtains an ACR refresh/access
+// token, either from a client secret or from Azure AD workload identity,
+// depending on how the provider was configured.
+func NewACRClient(httpClient *http.Client, loginServer string, tokenSource func(ctx context.Context) (string, error)) *ACRClient {
+	return &ACRClient{httpClient: httpClient, loginServer: loginServer, tokenSource: tokenSource}
+}
+
+// ListDigests implements Lister by listing the tags of every repository and
+// resolving each to its current manifest digest.
+func (c *ACRClient) ListDigests(ctx context.Context) (map[string]string, error) {
+	repos, err := c.listRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]string)
+	for _, repo := range repos {
+		tags, err := c.listTags(ctx, repo)
+		if err != nil {
+			return nil, err
+		}
+		for tag, digest := range tags {
+			out[repo+":"+tag] = digest
+		}
+	}
+	return out, nil
+}
+
+func (c *ACRClient) listRepositories(ctx context.Context) ([]string, error) {
+	var out struct {
+		Repositories []string `json:"repositories"`
+	}
+	if err := c.get(ctx, "/v2/_catalog", &out); err != nil {
+		return nil, fmt.Errorf("unable to list acr repositories: %w", err)
+	}
+	return out.Repositories, nil
+}
+
+func (c *ACRClient) listTags(ctx context.Context, repo string) (map[string]string, error) {
+	var out struct {
+		Manifests []struct {
+			Digest string   `json:"digest"`
+			Tags   []string `json:"tags"`
+		} `json:"manifests"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("/acr/v1/%s/_manifests", repo), &out); err != nil {
+		return nil, fmt.Errorf("unable to list acr tags for %s: %w", repo, err)
+	}
+
+	tags := make(map[string]string)
+	for _, m := range out.Manifests {
+		for _, t := range m.Tags {
+			tags[t] = m.Digest
+		}
+	}
+	return tags, nil
+}
+
+func (c *ACRClient) get(ctx context.Context, path string, out interface{}) error {
+	token, err := c.tokenSource(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to obtain acr token: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+c.loginServer+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code from acr: %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imageprovider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestACRClientListDigests(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.URL.Path == "/v2/_catalog":
+			w.Write([]byte(`{"repositories":["myapp"]}`))
+		case r.URL.Path == "/acr/v1/myapp/_manifests":
+			w.Write([]byte(`{"manifests":[{"digest":"sha256:abc","tags":["v1","latest"]}]}`))
+		default:
+			t.Fatalf("unexpected request path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	loginServer := strings.TrimPrefix(server.URL, "https://")
+	client := NewACRClient(server.Client(), loginServer, func(ctx context.Context) (string, error) {
+		return "test-token", nil
+	})
+
+	digests, err := client.ListDigests(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"myapp:v1":     "sha256:abc",
+		"myapp:latest": "sha256:abc",
+	}, digests)
+}
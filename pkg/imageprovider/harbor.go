@@ -0,0 +1,135 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imageprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// HarborClient lists tags and resolves digests against a self-hosted Harbor
+// registry. Harbor exposes both a Docker Registry v2 API and a richer
+// project-scoped REST API (which also fronts chartmuseum-style chart
+// repositories); this client uses the latter to enumerate repositories.
+type HarborClient struct {
+	httpClient *http.Client
+	address    string
+	project    string
+	username   string
+	password   string
+	robotToken string
+}
+
+// NewHarborClient creates a HarborClient. When robotToken is non-empty it is
+// used as the bearer token instead of basic auth with username/password.
+func NewHarborClient(httpClient *http.Client, address, project, username, password, robotToken string) *HarborClient {
+	return &HarborClient{
+		httpClient: httpClient,
+		address:    address,
+		project:    project,
+		username:   username,
+		password:   password,
+		robotToken: robotToken,
+	}
+}
+
+// ListDigests implements Lister.
+func (c *HarborClient) ListDigests(ctx context.Context) (map[string]string, error) {
+	repos, err := c.listRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]string)
+	for _, repo := range repos {
+		artifacts, err := c.listArtifacts(ctx, repo)
+		if err != nil {
+			return nil, err
+		}
+		for tag, digest := range artifacts {
+			out[repo+":"+tag] = digest
+		}
+	}
+	return out, nil
+}
+
+func (c *HarborClient) listRepositories(ctx context.Context) ([]string, error) {
+	var repos []struct {
+		Name string `json:"name"`
+	}
+	path := fmt.Sprintf("/api/v2.0/projects/%s/repositories", c.project)
+	if err := c.get(ctx, path, &repos); err != nil {
+		return nil, fmt.Errorf("unable to list harbor repositories: %w", err)
+	}
+
+	names := make([]string, 0, len(repos))
+	for _, r := range repos {
+		names = append(names, r.Name)
+	}
+	return names, nil
+}
+
+func (c *HarborClient) listArtifacts(ctx context.Context, repo string) (map[string]string, error) {
+	var artifacts []struct {
+		Digest string `json:"digest"`
+		Tags   []struct {
+			Name string `json:"name"`
+		} `json:"tags"`
+	}
+	// repo, as returned by listRepositories, is Harbor's own "{project}/{repo}"
+	// name; the artifacts endpoint takes the repo segment alone, and expects
+	// any further "/" in a nested repo name double-encoded as a single path
+	// segment.
+	repoName := strings.TrimPrefix(repo, c.project+"/")
+	repoName = strings.ReplaceAll(repoName, "/", "%252F")
+	path := fmt.Sprintf("/api/v2.0/projects/%s/repositories/%s/artifacts", c.project, repoName)
+	if err := c.get(ctx, path, &artifacts); err != nil {
+		return nil, fmt.Errorf("unable to list harbor artifacts for %s: %w", repo, err)
+	}
+
+	out := make(map[string]string)
+	for _, a := range artifacts {
+		for _, t := range a.Tags {
+			out[t.Name] = a.Digest
+		}
+	}
+	return out, nil
+}
+
+func (c *HarborClient) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.address+path, nil)
+	if err != nil {
+		return err
+	}
+	if c.robotToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.robotToken)
+	} else {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code from harbor: %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imageprovider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHarborClientListDigests(t *testing.T) {
+	var requestedPaths []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPaths = append(requestedPaths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+
+		// Match on the escaped path: r.URL.Path has already been decoded once,
+		// which would turn the double-encoded "%252F" into "%2F".
+		switch r.URL.EscapedPath() {
+		case "/api/v2.0/projects/myproj/repositories":
+			w.Write([]byte(`[{"name":"myproj/app"},{"name":"myproj/team/app"}]`))
+		case "/api/v2.0/projects/myproj/repositories/app/artifacts":
+			w.Write([]byte(`[{"digest":"sha256:app","tags":[{"name":"v1"}]}]`))
+		case "/api/v2.0/projects/myproj/repositories/team%252Fapp/artifacts":
+			w.Write([]byte(`[{"digest":"sha256:nested","tags":[{"name":"v2"}]}]`))
+		default:
+			t.Fatalf("unexpected request path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewHarborClient(server.Client(), server.URL, "myproj", "user", "pass", "")
+	digests, err := client.ListDigests(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"myproj/app:v1":      "sha256:app",
+		"myproj/team/app:v2": "sha256:nested",
+	}, digests)
+}
@@ -0,0 +1,151 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package imageprovider provides a shared, informer/reflector-style cache of
+// container image tags so that deployment triggers can react to a new push
+// within seconds instead of waiting for the next poll.
+package imageprovider
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Event represents a change observed for a repo/tag pair, either discovered
+// by polling the registry or delivered through a webhook.
+type Event struct {
+	ProviderName string
+	Repo         string
+	Tag          string
+	Digest       string
+}
+
+// Handler is notified whenever a new Event is observed.
+type Handler func(Event)
+
+// key uniquely identifies a watched repo/tag within a provider.
+type key struct {
+	repo string
+	tag  string
+}
+
+// Informer keeps an in-memory cache of the last known digest for each
+// repo/tag pair of a single provider, mirroring the client-go reflector
+// pattern: a periodic List seeds/refreshes the cache, while Watch (or, here,
+// webhook deliveries) pushes incremental updates in between.
+type Informer struct {
+	providerName string
+	lister       Lister
+	pullInterval time.Duration
+
+	mu    sync.Mutex
+	cache map[key]string
+
+	handlersMu sync.RWMutex
+	handlers   []Handler
+}
+
+// Lister lists the current digest of tags known to a provider. It is
+// implemented by each concrete provider client (Dockerhub, GCR, ECR, ACR,
+// Harbor, ...).
+type Lister interface {
+	ListDigests(ctx context.Context) (map[string]string, error) // keyed by "repo:tag"
+}
+
+// NewInformer creates an Informer that periodically reconciles its cache by
+// calling lister.ListDigests every pullInterval.
+func NewInformer(providerName string, lister Lister, pullInterval time.Duration) *Informer {
+	return &Informer{
+		providerName: providerName,
+		lister:       lister,
+		pullInterval: pullInterval,
+		cache:        make(map[key]string),
+	}
+}
+
+// AddEventHandler registers a handler to be called for every newly observed digest.
+func (i *Informer) AddEventHandler(h Handler) {
+	i.handlersMu.Lock()
+	defer i.handlersMu.Unlock()
+	i.handlers = append(i.handlers, h)
+}
+
+// Run blocks, periodically listing the registry until ctx is done.
+func (i *Informer) Run(ctx context.Context) {
+	ticker := time.NewTicker(i.pullInterval)
+	defer ticker.Stop()
+
+	i.reconcile(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			i.reconcile(ctx)
+		}
+	}
+}
+
+func (i *Informer) reconcile(ctx context.Context) {
+	digests, err := i.lister.ListDigests(ctx)
+	if err != nil {
+		return
+	}
+	for repoTag, digest := range digests {
+		i.observe(repoTag, digest)
+	}
+}
+
+// ObserveWebhookEvent feeds a digest update received out-of-band, e.g. from
+// the webhook receiver, into the cache.
+func (i *Informer) ObserveWebhookEvent(repo, tag, digest string) {
+	i.observe(repo+":"+tag, digest)
+}
+
+func (i *Informer) observe(repoTag, digest string) {
+	parts := splitRepoTag(repoTag)
+	if parts == nil {
+		return
+	}
+	k := key{repo: parts[0], tag: parts[1]}
+
+	i.mu.Lock()
+	old, ok := i.cache[k]
+	if ok && old == digest {
+		i.mu.Unlock()
+		return
+	}
+	i.cache[k] = digest
+	i.mu.Unlock()
+
+	i.handlersMu.RLock()
+	handlers := make([]Handler, len(i.handlers))
+	copy(handlers, i.handlers)
+	i.handlersMu.RUnlock()
+
+	ev := Event{ProviderName: i.providerName, Repo: k.repo, Tag: k.tag, Digest: digest}
+	for _, h := range handlers {
+		h(ev)
+	}
+}
+
+func splitRepoTag(repoTag string) []string {
+	for idx := len(repoTag) - 1; idx >= 0; idx-- {
+		if repoTag[idx] == ':' {
+			return []string{repoTag[:idx], repoTag[idx+1:]}
+		}
+	}
+	return nil
+}
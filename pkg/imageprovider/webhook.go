@@ -0,0 +1,145 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imageprovider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// Dispatcher receives a raw webhook payload already verified to come from the
+// named provider and turns it into cache updates for the matching Informer.
+type Dispatcher interface {
+	Dispatch(providerName string, payload []byte) error
+}
+
+// Receiver is an http.Handler that can be mounted onto Piped's HTTP server
+// to receive registry push events and dispatch them to the right provider
+// by name, verifying the HMAC signature and the source CIDR allowlist
+// configured for that provider.
+type Receiver struct {
+	providers map[string]receiverProvider
+	// byPath indexes providers that configured a dedicated Path, so a
+	// delivery can be routed by URL path alone, without relying on the
+	// "provider" query parameter.
+	byPath   map[string]string
+	dispatch Dispatcher
+}
+
+type receiverProvider struct {
+	secret       []byte
+	allowedCIDRs []*net.IPNet
+}
+
+// NewReceiver creates an empty Receiver. Providers must be registered with
+// RegisterProvider before any request referencing them can be served.
+func NewReceiver(dispatch Dispatcher) *Receiver {
+	return &Receiver{
+		providers: make(map[string]receiverProvider),
+		byPath:    make(map[string]string),
+		dispatch:  dispatch,
+	}
+}
+
+// RegisterProvider configures the HMAC secret and source CIDR allowlist used
+// to authenticate webhook deliveries for the given provider name. path is
+// the ImageProviderWebhookConfig.Path this provider's events are delivered
+// to; when non-empty, requests to that path are routed to this provider
+// without needing a "provider" query parameter.
+func (r *Receiver) RegisterProvider(name, path string, secret []byte, allowedCIDRs []string) error {
+	nets := make([]*net.IPNet, 0, len(allowedCIDRs))
+	for _, c := range allowedCIDRs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return fmt.Errorf("invalid allowed source CIDR %q for provider %s: %w", c, name, err)
+		}
+		nets = append(nets, n)
+	}
+	r.providers[name] = receiverProvider{secret: secret, allowedCIDRs: nets}
+	if path != "" {
+		r.byPath[path] = name
+	}
+	return nil
+}
+
+// ServeHTTP handles a single webhook delivery, resolving the provider by the
+// request path (when the provider registered a dedicated Path) and falling
+// back to the "provider" query parameter otherwise.
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	name := req.URL.Query().Get("provider")
+	if n, ok := r.byPath[req.URL.Path]; ok {
+		name = n
+	}
+	p, ok := r.providers[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown image provider %q", name), http.StatusNotFound)
+		return
+	}
+
+	if len(p.allowedCIDRs) > 0 && !sourceAllowed(req.RemoteAddr, p.allowedCIDRs) {
+		http.Error(w, "source address not allowed", http.StatusForbidden)
+		return
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, "unable to read request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(p.secret) > 0 && !validSignature(p.secret, body, req.Header.Get("X-Hub-Signature-256")) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.dispatch.Dispatch(name, body); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func sourceAllowed(remoteAddr string, allowed []*net.IPNet) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range allowed {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func validSignature(secret, body []byte, header string) bool {
+	const prefix = "sha256="
+	if len(header) <= len(prefix) || header[:len(prefix)] != prefix {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(header[len(prefix):]), []byte(expected))
+}
@@ -0,0 +1,152 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imageprovider
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDispatcher struct {
+	provider string
+	payload  []byte
+	err      error
+}
+
+func (d *fakeDispatcher) Dispatch(providerName string, payload []byte) error {
+	d.provider = providerName
+	d.payload = payload
+	return d.err
+}
+
+func sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestReceiverRoutesByPath(t *testing.T) {
+	dispatcher := &fakeDispatcher{}
+	receiver := NewReceiver(dispatcher)
+	require.NoError(t, receiver.RegisterProvider("harbor", "/webhooks/harbor", nil, nil))
+
+	body := []byte(`{"type":"PUSH_ARTIFACT"}`)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/harbor", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	receiver.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "harbor", dispatcher.provider)
+	assert.Equal(t, body, dispatcher.payload)
+}
+
+func TestReceiverFallsBackToQueryParameter(t *testing.T) {
+	dispatcher := &fakeDispatcher{}
+	receiver := NewReceiver(dispatcher)
+	require.NoError(t, receiver.RegisterProvider("acr", "", nil, nil))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks?provider=acr", bytes.NewReader([]byte("{}")))
+	w := httptest.NewRecorder()
+
+	receiver.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "acr", dispatcher.provider)
+}
+
+func TestReceiverRejectsInvalidSignature(t *testing.T) {
+	dispatcher := &fakeDispatcher{}
+	receiver := NewReceiver(dispatcher)
+	secret := []byte("s3cr3t")
+	require.NoError(t, receiver.RegisterProvider("harbor", "/webhooks/harbor", secret, nil))
+
+	body := []byte(`{"type":"PUSH_ARTIFACT"}`)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/harbor", bytes.NewReader(body))
+	req.Header.Set("X-Hub-Signature-256", "sha256=deadbeef")
+	w := httptest.NewRecorder()
+
+	receiver.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, dispatcher.provider)
+}
+
+func TestReceiverAcceptsValidSignature(t *testing.T) {
+	dispatcher := &fakeDispatcher{}
+	receiver := NewReceiver(dispatcher)
+	secret := []byte("s3cr3t")
+	require.NoError(t, receiver.RegisterProvider("harbor", "/webhooks/harbor", secret, nil))
+
+	body := []byte(`{"type":"PUSH_ARTIFACT"}`)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/harbor", bytes.NewReader(body))
+	req.Header.Set("X-Hub-Signature-256", sign(secret, body))
+	w := httptest.NewRecorder()
+
+	receiver.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "harbor", dispatcher.provider)
+}
+
+func TestReceiverRejectsDisallowedSourceCIDR(t *testing.T) {
+	dispatcher := &fakeDispatcher{}
+	receiver := NewReceiver(dispatcher)
+	require.NoError(t, receiver.RegisterProvider("harbor", "/webhooks/harbor", nil, []string{"10.0.0.0/8"}))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/harbor", bytes.NewReader([]byte("{}")))
+	req.RemoteAddr = "192.168.1.1:12345"
+	w := httptest.NewRecorder()
+
+	receiver.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, dispatcher.provider)
+}
+
+func TestReceiverAllowsMatchingSourceCIDR(t *testing.T) {
+	dispatcher := &fakeDispatcher{}
+	receiver := NewReceiver(dispatcher)
+	require.NoError(t, receiver.RegisterProvider("harbor", "/webhooks/harbor", nil, []string{"10.0.0.0/8"}))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/harbor", bytes.NewReader([]byte("{}")))
+	req.RemoteAddr = "10.1.2.3:12345"
+	w := httptest.NewRecorder()
+
+	receiver.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "harbor", dispatcher.provider)
+}
+
+func TestReceiverUnknownProvider(t *testing.T) {
+	dispatcher := &fakeDispatcher{}
+	receiver := NewReceiver(dispatcher)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks?provider=unknown", bytes.NewReader([]byte("{}")))
+	w := httptest.NewRecorder()
+
+	receiver.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,115 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plugin holds the stable contract third-party binaries implement to
+// be loaded by Piped as out-of-process executor plugins. executor.proto
+// documents the wire contract; executor.pb.go/executor_grpc.pb.go carry it
+// by hand rather than through protoc, since the message types are sent with
+// the "json" codec (codec.go), not protobuf encoding. This file adapts the
+// stream-based raw client/server onto the simpler callback-style
+// ExecutorClient/ExecutorServer that the rest of Piped and plugin authors
+// actually use, so neither side has to deal with the ExecuteResponse stream
+// directly.
+package plugin
+
+import "context"
+
+// ExecutorClient is the Piped-side view of a running executor plugin. onLog
+// is called for every log line streamed back by the plugin as the stage
+// progresses.
+type ExecutorClient interface {
+	Execute(ctx context.Context, req ExecuteRequest, onLog func(line string)) (StageStatus, error)
+	Cancel(ctx context.Context, executionID string) error
+	HealthCheck(ctx context.Context) (bool, error)
+}
+
+// ExecutorServer is the interface a plugin binary implements; Serve wraps it
+// behind the gRPC server defined in executor_grpc.pb.go and hands it to
+// go-plugin.
+type ExecutorServer interface {
+	Execute(ctx context.Context, req ExecuteRequest, onLog func(line string)) (StageStatus, error)
+	Cancel(ctx context.Context, executionID string) error
+	HealthCheck(ctx context.Context) (bool, error)
+}
+
+// grpcExecutorClient adapts the raw, streaming ExecutorPluginClient onto
+// ExecutorClient by draining the ExecuteResponse stream into onLog calls and
+// returning only the final status.
+type grpcExecutorClient struct {
+	raw ExecutorPluginClient
+}
+
+func (c *grpcExecutorClient) Execute(ctx context.Context, req ExecuteRequest, onLog func(line string)) (StageStatus, error) {
+	stream, err := c.raw.Execute(ctx, &req)
+	if err != nil {
+		return StageUnknown, err
+	}
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return StageUnknown, err
+		}
+		if resp.Completed {
+			return resp.Status, nil
+		}
+		onLog(resp.LogLine)
+	}
+}
+
+func (c *grpcExecutorClient) Cancel(ctx context.Context, executionID string) error {
+	_, err := c.raw.Cancel(ctx, &CancelRequest{ExecutionId: executionID})
+	return err
+}
+
+func (c *grpcExecutorClient) HealthCheck(ctx context.Context) (bool, error) {
+	resp, err := c.raw.HealthCheck(ctx, &HealthCheckRequest{})
+	if err != nil {
+		return false, err
+	}
+	return resp.Healthy, nil
+}
+
+// grpcExecutorServer adapts an ExecutorServer implementation onto the raw,
+// streaming ExecutorPluginServer by turning each onLog callback into an
+// ExecuteResponse message and sending a final one carrying the returned
+// status.
+type grpcExecutorServer struct {
+	UnimplementedExecutorPluginServer
+	impl ExecutorServer
+}
+
+func (s *grpcExecutorServer) Execute(req *ExecuteRequest, stream ExecutorPlugin_ExecuteServer) error {
+	status, err := s.impl.Execute(stream.Context(), *req, func(line string) {
+		stream.Send(&ExecuteResponse{LogLine: line})
+	})
+	if err != nil {
+		return err
+	}
+	return stream.Send(&ExecuteResponse{Completed: true, Status: status})
+}
+
+func (s *grpcExecutorServer) Cancel(ctx context.Context, req *CancelRequest) (*CancelResponse, error) {
+	if err := s.impl.Cancel(ctx, req.ExecutionId); err != nil {
+		return nil, err
+	}
+	return &CancelResponse{}, nil
+}
+
+func (s *grpcExecutorServer) HealthCheck(ctx context.Context, req *HealthCheckRequest) (*HealthCheckResponse, error) {
+	healthy, err := s.impl.HealthCheck(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &HealthCheckResponse{Healthy: healthy}, nil
+}
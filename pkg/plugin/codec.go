@@ -0,0 +1,47 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// jsonCodecName is the gRPC content-subtype the ExecutorPlugin service is
+// carried over. The message types in executor.pb.go are plain Go structs,
+// not generated protobuf messages, so they don't satisfy proto.Message and
+// can't go through grpc-go's default "proto" codec; registering this codec
+// and requesting it with grpc.CallContentSubtype on every call (see
+// executor_grpc.pb.go) makes both the client and the server, which pick
+// their codec from the request's content-subtype, encode with
+// encoding/json instead.
+const jsonCodecName = "json"
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return jsonCodecName }
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
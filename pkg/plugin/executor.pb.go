@@ -0,0 +1,140 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The message types below mirror executor.proto by hand; they are carried
+// over gRPC using the "json" codec registered in codec.go rather than
+// protobuf's wire format, so they're plain structs and don't need to
+// implement proto.Message. Keep them in sync with executor.proto by hand.
+
+package plugin
+
+// StageStatus mirrors the StageStatus enum in executor.proto.
+type StageStatus int32
+
+const (
+	StageStatus_STAGE_UNKNOWN   StageStatus = 0
+	StageStatus_STAGE_SUCCESS   StageStatus = 1
+	StageStatus_STAGE_FAILURE   StageStatus = 2
+	StageStatus_STAGE_CANCELLED StageStatus = 3
+)
+
+// Aliases kept for the hand-written ExecutorClient/ExecutorServer wrappers in
+// client.go, which speak in terms of these shorter names.
+const (
+	StageUnknown   = StageStatus_STAGE_UNKNOWN
+	StageSuccess   = StageStatus_STAGE_SUCCESS
+	StageFailure   = StageStatus_STAGE_FAILURE
+	StageCancelled = StageStatus_STAGE_CANCELLED
+)
+
+// ExecuteRequest carries everything a plugin needs to run a single stage.
+type ExecuteRequest struct {
+	ExecutionId  string `json:"execution_id,omitempty"`
+	StageName    string `json:"stage_name,omitempty"`
+	StageConfig  []byte `json:"stage_config,omitempty"`
+	Deployment   []byte `json:"deployment,omitempty"`
+	PluginConfig []byte `json:"plugin_config,omitempty"`
+}
+
+func (r *ExecuteRequest) GetExecutionId() string {
+	if r != nil {
+		return r.ExecutionId
+	}
+	return ""
+}
+
+func (r *ExecuteRequest) GetStageName() string {
+	if r != nil {
+		return r.StageName
+	}
+	return ""
+}
+
+func (r *ExecuteRequest) GetStageConfig() []byte {
+	if r != nil {
+		return r.StageConfig
+	}
+	return nil
+}
+
+func (r *ExecuteRequest) GetDeployment() []byte {
+	if r != nil {
+		return r.Deployment
+	}
+	return nil
+}
+
+func (r *ExecuteRequest) GetPluginConfig() []byte {
+	if r != nil {
+		return r.PluginConfig
+	}
+	return nil
+}
+
+// ExecuteResponse is a single message of the Execute response stream. LogLine
+// is set on every message; Completed and Status are only meaningful on the
+// final message.
+type ExecuteResponse struct {
+	LogLine   string      `json:"log_line,omitempty"`
+	Completed bool        `json:"completed,omitempty"`
+	Status    StageStatus `json:"status,omitempty"`
+}
+
+func (r *ExecuteResponse) GetLogLine() string {
+	if r != nil {
+		return r.LogLine
+	}
+	return ""
+}
+
+func (r *ExecuteResponse) GetCompleted() bool {
+	if r != nil {
+		return r.Completed
+	}
+	return false
+}
+
+func (r *ExecuteResponse) GetStatus() StageStatus {
+	if r != nil {
+		return r.Status
+	}
+	return StageStatus_STAGE_UNKNOWN
+}
+
+// CancelRequest asks a running Execute call to stop as soon as possible.
+type CancelRequest struct {
+	ExecutionId string `json:"execution_id,omitempty"`
+}
+
+func (r *CancelRequest) GetExecutionId() string {
+	if r != nil {
+		return r.ExecutionId
+	}
+	return ""
+}
+
+type CancelResponse struct{}
+
+type HealthCheckRequest struct{}
+
+type HealthCheckResponse struct {
+	Healthy bool `json:"healthy,omitempty"`
+}
+
+func (r *HealthCheckResponse) GetHealthy() bool {
+	if r != nil {
+		return r.Healthy
+	}
+	return false
+}
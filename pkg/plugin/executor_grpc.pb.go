@@ -0,0 +1,211 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file hand-implements the client/server plumbing protoc-gen-go-grpc
+// would normally generate from executor.proto. It isn't run through the
+// real protobuf toolchain: the message types in executor.pb.go are plain
+// structs, not protoreflect.ProtoMessage, so every call here forces the
+// "json" codec registered in codec.go instead of grpc-go's default proto
+// codec. Keep this in sync with executor.proto by hand.
+
+package plugin
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ExecutorPluginClient is the raw gRPC client for the ExecutorPlugin
+// service. Piped never talks to this directly; it goes through the
+// friendlier ExecutorClient in client.go instead.
+type ExecutorPluginClient interface {
+	Execute(ctx context.Context, in *ExecuteRequest, opts ...grpc.CallOption) (ExecutorPlugin_ExecuteClient, error)
+	Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelResponse, error)
+	HealthCheck(ctx context.Context, in *HealthCheckRequest, opts ...grpc.CallOption) (*HealthCheckResponse, error)
+}
+
+type executorPluginClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewExecutorPluginClient returns a raw ExecutorPlugin client bound to cc.
+func NewExecutorPluginClient(cc grpc.ClientConnInterface) ExecutorPluginClient {
+	return &executorPluginClient{cc}
+}
+
+func (c *executorPluginClient) Execute(ctx context.Context, in *ExecuteRequest, opts ...grpc.CallOption) (ExecutorPlugin_ExecuteClient, error) {
+	opts = append(opts, grpc.CallContentSubtype(jsonCodecName))
+	stream, err := c.cc.NewStream(ctx, &executorPluginExecuteStreamDesc, "/plugin.ExecutorPlugin/Execute", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &executorPluginExecuteClient{stream}
+	if err := x.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// ExecutorPlugin_ExecuteClient is the client-side handle on the Execute
+// response stream, one ExecuteResponse per Recv call.
+type ExecutorPlugin_ExecuteClient interface {
+	Recv() (*ExecuteResponse, error)
+	grpc.ClientStream
+}
+
+type executorPluginExecuteClient struct {
+	grpc.ClientStream
+}
+
+func (x *executorPluginExecuteClient) Recv() (*ExecuteResponse, error) {
+	m := new(ExecuteResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *executorPluginClient) Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelResponse, error) {
+	opts = append(opts, grpc.CallContentSubtype(jsonCodecName))
+	out := new(CancelResponse)
+	if err := c.cc.Invoke(ctx, "/plugin.ExecutorPlugin/Cancel", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *executorPluginClient) HealthCheck(ctx context.Context, in *HealthCheckRequest, opts ...grpc.CallOption) (*HealthCheckResponse, error) {
+	opts = append(opts, grpc.CallContentSubtype(jsonCodecName))
+	out := new(HealthCheckResponse)
+	if err := c.cc.Invoke(ctx, "/plugin.ExecutorPlugin/HealthCheck", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ExecutorPluginServer is the raw gRPC server interface for the
+// ExecutorPlugin service. Plugin authors implement the friendlier
+// ExecutorServer in client.go instead; grpcExecutorServer bridges the two.
+type ExecutorPluginServer interface {
+	Execute(*ExecuteRequest, ExecutorPlugin_ExecuteServer) error
+	Cancel(context.Context, *CancelRequest) (*CancelResponse, error)
+	HealthCheck(context.Context, *HealthCheckRequest) (*HealthCheckResponse, error)
+}
+
+// ExecutorPlugin_ExecuteServer is the server-side handle on the Execute
+// response stream.
+type ExecutorPlugin_ExecuteServer interface {
+	Send(*ExecuteResponse) error
+	grpc.ServerStream
+}
+
+type executorPluginExecuteServer struct {
+	grpc.ServerStream
+}
+
+func (x *executorPluginExecuteServer) Send(m *ExecuteResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// RegisterExecutorPluginServer registers srv to handle ExecutorPlugin calls
+// received over s.
+func RegisterExecutorPluginServer(s grpc.ServiceRegistrar, srv ExecutorPluginServer) {
+	s.RegisterService(&executorPluginServiceDesc, srv)
+}
+
+func executorPluginExecuteHandler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExecuteRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ExecutorPluginServer).Execute(m, &executorPluginExecuteServer{stream})
+}
+
+func executorPluginCancelHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExecutorPluginServer).Cancel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/plugin.ExecutorPlugin/Cancel"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExecutorPluginServer).Cancel(ctx, req.(*CancelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func executorPluginHealthCheckHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthCheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExecutorPluginServer).HealthCheck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/plugin.ExecutorPlugin/HealthCheck"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExecutorPluginServer).HealthCheck(ctx, req.(*HealthCheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var executorPluginServiceDesc = grpc.ServiceDesc{
+	ServiceName: "plugin.ExecutorPlugin",
+	HandlerType: (*ExecutorPluginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Cancel", Handler: executorPluginCancelHandler},
+		{MethodName: "HealthCheck", Handler: executorPluginHealthCheckHandler},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Execute",
+			Handler:       executorPluginExecuteHandler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "executor.proto",
+}
+
+var executorPluginExecuteStreamDesc = grpc.StreamDesc{
+	StreamName:    "Execute",
+	ServerStreams: true,
+}
+
+// errUnimplemented is returned by UnimplementedExecutorPluginServer so that a
+// plugin built against an older contract fails loudly instead of panicking.
+var errUnimplemented = status.Error(codes.Unimplemented, "method not implemented")
+
+// UnimplementedExecutorPluginServer can be embedded in an ExecutorPluginServer
+// implementation to satisfy the interface for methods not yet implemented.
+type UnimplementedExecutorPluginServer struct{}
+
+func (UnimplementedExecutorPluginServer) Execute(*ExecuteRequest, ExecutorPlugin_ExecuteServer) error {
+	return errUnimplemented
+}
+
+func (UnimplementedExecutorPluginServer) Cancel(context.Context, *CancelRequest) (*CancelResponse, error) {
+	return nil, errUnimplemented
+}
+
+func (UnimplementedExecutorPluginServer) HealthCheck(context.Context, *HealthCheckRequest) (*HealthCheckResponse, error) {
+	return nil, errUnimplemented
+}
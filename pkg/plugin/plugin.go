@@ -0,0 +1,57 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+
+	goplugin "github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+)
+
+// ExecutorGRPCPlugin adapts an ExecutorClient/ExecutorServer pair to
+// go-plugin's GRPCPlugin interface, bridging through the generated raw
+// ExecutorPluginClient/ExecutorPluginServer, so it can be dispensed through a
+// goplugin.Client on the Piped side, or served with Serve on the plugin side.
+type ExecutorGRPCPlugin struct {
+	goplugin.Plugin
+	Impl ExecutorServer
+}
+
+func (p *ExecutorGRPCPlugin) GRPCServer(_ *goplugin.GRPCBroker, s *grpc.Server) error {
+	RegisterExecutorPluginServer(s, &grpcExecutorServer{impl: p.Impl})
+	return nil
+}
+
+func (p *ExecutorGRPCPlugin) GRPCClient(_ context.Context, _ *goplugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
+	return &grpcExecutorClient{raw: NewExecutorPluginClient(c)}, nil
+}
+
+// Serve starts a plugin binary implementing impl and blocks, handing control
+// over to go-plugin until Piped disconnects. Third-party plugin binaries
+// call this from their main function.
+func Serve(impl ExecutorServer) {
+	goplugin.Serve(&goplugin.ServeConfig{
+		HandshakeConfig: goplugin.HandshakeConfig{
+			ProtocolVersion:  1,
+			MagicCookieKey:   "PIPED_EXECUTOR_PLUGIN",
+			MagicCookieValue: "pipe-cd",
+		},
+		Plugins: map[string]goplugin.Plugin{
+			"executor": &ExecutorGRPCPlugin{Impl: impl},
+		},
+		GRPCServer: goplugin.DefaultGRPCServer,
+	})
+}